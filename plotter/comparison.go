@@ -0,0 +1,246 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package plotter
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"code.google.com/p/plotinum/plot"
+	"code.google.com/p/plotinum/vg"
+)
+
+var (
+	// DefaultComparisonGlyphStyle is a dot.  A ComparisonSeries
+	// scales its Radius up per point, when drawing, according to the
+	// number of Changes backing that point's estimate.
+	DefaultComparisonGlyphStyle = plot.GlyphStyle{
+		Color:  color.Black,
+		Radius: vg.Points(2),
+		Shape:  plot.CircleGlyph{},
+	}
+
+	// DefaultComparisonWhiskerStyle is a hairline.
+	DefaultComparisonWhiskerStyle = plot.LineStyle{
+		Color: color.Black,
+		Width: vg.Points(0.5),
+	}
+
+	// DefaultBoringBandColor is a light, translucent gray, so that
+	// whiskers and glyphs remain visible through the band.
+	DefaultBoringBandColor = color.NRGBA{R: 128, G: 128, B: 128, A: 60}
+)
+
+// A ComparisonPoint is one before/after measurement in a
+// ComparisonSeries: a center estimate with a bootstrap confidence
+// interval, and the raw deltas the estimate was computed from.
+type ComparisonPoint struct {
+	// Label names the benchmark this point summarizes.
+	Label string
+
+	// Center is the point estimate of the change, e.g. a ratio
+	// where 1.0 means "no change".
+	Center float64
+
+	// Low and High are the bounds of the bootstrap confidence
+	// interval around Center.
+	Low, High float64
+
+	// Changes holds the raw per-run deltas the interval was
+	// computed from.  Its length controls the glyph's radius, so
+	// noisier estimates draw visually larger.
+	Changes []float64
+}
+
+// ComparisonSeries implements the Plotter interface, drawing one
+// whisker-and-glyph per ComparisonPoint against a nominal x axis of
+// benchmark names.  It parallels QuartPlot's structure, but targets
+// before/after benchmark delta visualization rather than a
+// distribution of values.
+type ComparisonSeries struct {
+	// Points are the series' data, one per benchmark, in the order
+	// they are drawn along the x axis.
+	Points []ComparisonPoint
+
+	// GlyphStyle is the base style for each point's center glyph.
+	// Its Radius is scaled per point by the number of Changes.
+	GlyphStyle plot.GlyphStyle
+
+	// WhiskerStyle is the line style used to draw each point's
+	// Low-to-High whisker.
+	WhiskerStyle plot.LineStyle
+
+	// LogScale should be set to true if this series is drawn
+	// against a plot.LogScale y axis.  DataRange then uses a
+	// point's Center in place of a Low at or below zero; Center and
+	// High themselves are reported as they are.
+	LogScale bool
+}
+
+// NewComparisonSeries returns a new ComparisonSeries representing pts.
+func NewComparisonSeries(pts []ComparisonPoint) *ComparisonSeries {
+	return &ComparisonSeries{
+		Points:       pts,
+		GlyphStyle:   DefaultComparisonGlyphStyle,
+		WhiskerStyle: DefaultComparisonWhiskerStyle,
+	}
+}
+
+// radius returns the glyph radius for the i'th point, scaled by the
+// square root of its number of Changes around the series' base
+// GlyphStyle.Radius.
+func (s *ComparisonSeries) radius(i int) vg.Length {
+	n := len(s.Points[i].Changes)
+	if n <= 1 {
+		return s.GlyphStyle.Radius
+	}
+	return s.GlyphStyle.Radius * vg.Length(math.Sqrt(float64(n)))
+}
+
+// Plot draws the whiskers and glyphs of s, implementing the
+// plot.Plotter interface.
+func (s *ComparisonSeries) Plot(da plot.DrawArea, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&da)
+	for i, p := range s.Points {
+		x := trX(float64(i))
+		if !da.ContainsX(x) {
+			continue
+		}
+
+		da.StrokeLine2(s.WhiskerStyle, x, trY(p.Low), x, trY(p.High))
+
+		gs := s.GlyphStyle
+		gs.Radius = s.radius(i)
+		da.DrawGlyph(gs, plot.Point{X: x, Y: trY(p.Center)})
+	}
+}
+
+// DataRange returns the minimum and maximum x and y values,
+// implementing the plot.DataRanger interface.  X ranges over the
+// point indices, so a NominalX axis built from Labels lines up;
+// Y ranges over the full span of every whisker.
+func (s *ComparisonSeries) DataRange() (xmin, xmax, ymin, ymax float64) {
+	ymin, ymax = math.Inf(1), math.Inf(-1)
+	for _, p := range s.Points {
+		lo := p.Low
+		if s.LogScale && lo <= 0 {
+			lo = p.Center
+		}
+		ymin = math.Min(ymin, lo)
+		ymax = math.Max(ymax, p.High)
+	}
+	return 0, float64(len(s.Points) - 1), ymin, ymax
+}
+
+// GlyphBoxes returns a slice of GlyphBoxes for the plot, implementing
+// the plot.GlyphBoxer interface.
+func (s *ComparisonSeries) GlyphBoxes(plt *plot.Plot) []plot.GlyphBox {
+	bs := make([]plot.GlyphBox, len(s.Points))
+	for i, p := range s.Points {
+		bs[i].X = plt.X.Norm(float64(i))
+		bs[i].Y = plt.Y.Norm(p.Center)
+		gs := s.GlyphStyle
+		gs.Radius = s.radius(i)
+		bs[i].Rect = gs.Rect()
+	}
+	return bs
+}
+
+// Labels returns the point labels in order, for passing to
+// plot.Plot's NominalX so that benchmark names become the x tick
+// labels, e.g. plt.NominalX(series.Labels()...).
+func (s *ComparisonSeries) Labels() []string {
+	labels := make([]string, len(s.Points))
+	for i, p := range s.Points {
+		labels[i] = p.Label
+	}
+	return labels
+}
+
+// Highlight returns a *Labels tagging every point whose Center
+// differs from 1 by more than threshold, so that only the
+// statistically interesting results are labeled.
+func (s *ComparisonSeries) Highlight(threshold float64) (*Labels, error) {
+	var idxs []int
+	for i, p := range s.Points {
+		if math.Abs(p.Center-1) > threshold {
+			idxs = append(idxs, i)
+		}
+	}
+
+	ls, err := NewLabels(comparisonHighlight{s, idxs})
+	if err != nil {
+		return nil, err
+	}
+	ls.XOffset += s.GlyphStyle.Radius / 2
+	ls.YOffset += s.GlyphStyle.Radius / 2
+	return ls, nil
+}
+
+// comparisonHighlight implements the Labeller-backing XYLabels
+// interface expected by NewLabels, for the points selected by
+// ComparisonSeries.Highlight.
+type comparisonHighlight struct {
+	s    *ComparisonSeries
+	idxs []int
+}
+
+func (h comparisonHighlight) Len() int { return len(h.idxs) }
+
+func (h comparisonHighlight) XY(i int) (float64, float64) {
+	return float64(h.idxs[i]), h.s.Points[h.idxs[i]].Center
+}
+
+func (h comparisonHighlight) Label(i int) string {
+	return fmt.Sprintf("%.3g", h.s.Points[h.idxs[i]].Center)
+}
+
+// A BoringBand draws a translucent horizontal band across the full
+// width of the plot, marking a range of y values (e.g. ±threshold
+// around 1.0 for a ratio comparison) as not statistically
+// interesting, so the eye is drawn to whiskers that fall outside it.
+type BoringBand struct {
+	// Low and High are the y bounds of the band.
+	Low, High float64
+
+	// Color is the band's fill color; it should usually include
+	// some transparency so whiskers remain visible through it.
+	Color color.Color
+}
+
+// NewBoringBand returns a BoringBand spanning center-threshold to
+// center+threshold.
+func NewBoringBand(center, threshold float64) *BoringBand {
+	return &BoringBand{
+		Low:   center - threshold,
+		High:  center + threshold,
+		Color: DefaultBoringBandColor,
+	}
+}
+
+// Plot draws the band, implementing the plot.Plotter interface.
+func (b *BoringBand) Plot(da plot.DrawArea, plt *plot.Plot) {
+	_, trY := plt.Transforms(&da)
+	y0, y1 := trY(b.Low), trY(b.High)
+
+	var path vg.Path
+	path.Move(da.Min.X, y0)
+	path.Line(da.Max.X, y0)
+	path.Line(da.Max.X, y1)
+	path.Line(da.Min.X, y1)
+	path.Close()
+
+	da.SetColor(b.Color)
+	da.Fill(path)
+}
+
+// DataRange returns the minimum and maximum x and y values,
+// implementing the plot.DataRanger interface.  The band spans
+// whatever x range the rest of the plot establishes, so it reports an
+// empty x range of its own.
+func (b *BoringBand) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return math.Inf(1), math.Inf(-1), b.Low, b.High
+}
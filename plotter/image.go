@@ -0,0 +1,132 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package plotter
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"code.google.com/p/plotinum/plot"
+	"code.google.com/p/plotinum/vg"
+)
+
+// Image implements the Plotter interface, drawing a raster image
+// stretched to cover the given X and Y data extents.
+type Image struct {
+	// Img is the image to draw.
+	Img image.Image
+
+	// Xmin, Xmax, Ymin, Ymax are the data-space extents the image
+	// is stretched to cover.
+	Xmin, Xmax, Ymin, Ymax float64
+}
+
+// NewImage returns a new Image covering the given data extents.
+func NewImage(img image.Image, xmin, ymin, xmax, ymax float64) *Image {
+	return &Image{Img: img, Xmin: xmin, Ymin: ymin, Xmax: xmax, Ymax: ymax}
+}
+
+// Plot draws the image, implementing the plot.Plotter interface.
+func (im *Image) Plot(da plot.DrawArea, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&da)
+	rect := vg.Rectangle{
+		Min: vg.Point{X: trX(im.Xmin), Y: trY(im.Ymin)},
+		Max: vg.Point{X: trX(im.Xmax), Y: trY(im.Ymax)},
+	}
+	// da.Canvas, not da itself: plot.DrawArea only promotes the
+	// vg.Canvas method set it embeds, so asserting on da would never
+	// see a concrete canvas's own DrawImage method.
+	vg.DrawImageOrApproximate(da.Canvas, rect, im.Img)
+}
+
+// DataRange returns the minimum and maximum x and y values,
+// implementing the plot.DataRanger interface.
+func (im *Image) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return im.Xmin, im.Xmax, im.Ymin, im.Ymax
+}
+
+// A GridXYZ describes a grid of Z values, located at the X coordinate
+// of each column and the Y coordinate of each row.
+type GridXYZ interface {
+	// Nx and Ny return the number of columns and rows in the grid.
+	Nx() int
+	Ny() int
+
+	// X and Y return the coordinate of column c or row r.
+	X(c int) float64
+	Y(r int) float64
+
+	// Z returns the value at column c, row r.
+	Z(c, r int) float64
+}
+
+// A Colormap converts a Z value, normalized to [0, 1], to a color.
+type Colormap func(z float64) color.Color
+
+// HeatMap implements the Plotter interface, rasterizing a GridXYZ
+// into an image via a Colormap and drawing it with a single
+// DrawImage call, which is dramatically faster than drawing one
+// rectangle per cell.
+type HeatMap struct {
+	GridXYZ
+	Colormap Colormap
+}
+
+// NewHeatMap returns a new HeatMap of g, colored by cm.
+func NewHeatMap(g GridXYZ, cm Colormap) *HeatMap {
+	return &HeatMap{GridXYZ: g, Colormap: cm}
+}
+
+// rasterize converts the grid to an RGBA image via Colormap, one
+// pixel per cell.  If every cell holds the same value (including a
+// 1x1 grid), z is normalized to 0.5 rather than dividing by zero.
+func (h *HeatMap) rasterize() *image.RGBA {
+	nx, ny := h.Nx(), h.Ny()
+
+	zmin, zmax := math.Inf(1), math.Inf(-1)
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			z := h.Z(i, j)
+			zmin = math.Min(zmin, z)
+			zmax = math.Max(zmax, z)
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, nx, ny))
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			z := 0.5
+			if zmax > zmin {
+				z = (h.Z(i, j) - zmin) / (zmax - zmin)
+			}
+			// Rows run bottom-to-top in data space but
+			// top-to-bottom in image space.
+			img.Set(i, ny-1-j, h.Colormap(z))
+		}
+	}
+	return img
+}
+
+// Plot rasterizes the grid and draws it, implementing the
+// plot.Plotter interface.
+func (h *HeatMap) Plot(da plot.DrawArea, plt *plot.Plot) {
+	nx, ny := h.Nx(), h.Ny()
+	img := h.rasterize()
+
+	trX, trY := plt.Transforms(&da)
+	rect := vg.Rectangle{
+		Min: vg.Point{X: trX(h.X(0)), Y: trY(h.Y(0))},
+		Max: vg.Point{X: trX(h.X(nx - 1)), Y: trY(h.Y(ny - 1))},
+	}
+	vg.DrawImageOrApproximate(da.Canvas, rect, img)
+}
+
+// DataRange returns the minimum and maximum x and y values,
+// implementing the plot.DataRanger interface.
+func (h *HeatMap) DataRange() (xmin, xmax, ymin, ymax float64) {
+	nx, ny := h.Nx(), h.Ny()
+	return h.X(0), h.X(nx - 1), h.Y(0), h.Y(ny - 1)
+}
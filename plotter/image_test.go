@@ -0,0 +1,46 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package plotter
+
+import (
+	"image/color"
+	"testing"
+)
+
+// flatGrid is a GridXYZ whose every cell holds the same value, the
+// case that used to make HeatMap.rasterize divide by zero.
+type flatGrid struct {
+	nx, ny int
+	z      float64
+}
+
+func (g flatGrid) Nx() int            { return g.nx }
+func (g flatGrid) Ny() int            { return g.ny }
+func (g flatGrid) X(c int) float64    { return float64(c) }
+func (g flatGrid) Y(r int) float64    { return float64(r) }
+func (g flatGrid) Z(c, r int) float64 { return g.z }
+
+// TestHeatMapFlatGridNormalization checks that a flat (or 1x1) grid,
+// where zmax == zmin, normalizes to 0.5 rather than NaN.
+func TestHeatMapFlatGridNormalization(t *testing.T) {
+	for _, g := range []flatGrid{
+		{nx: 3, ny: 3, z: 7},
+		{nx: 1, ny: 1, z: 0},
+	} {
+		var got []float64
+		h := NewHeatMap(g, func(z float64) color.Color {
+			got = append(got, z)
+			return color.Black
+		})
+		h.rasterize()
+
+		for _, z := range got {
+			if z != 0.5 {
+				t.Errorf("flatGrid{nx:%d,ny:%d,z:%g}: Colormap got z=%v, want 0.5",
+					g.nx, g.ny, g.z, z)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package vg
+
+import "math"
+
+// epsilon is the IEEE 754 float64 machine epsilon.  It bounds how
+// finely FlattenArc subdivides an arc: it stops once the remaining
+// sweep falls below sqrt(epsilon), about 1.49e-8 radians.
+const epsilon = 2.220446049250313e-16
+
+// FlattenArc approximates comp, which must be an ArcComp, with a
+// series of cubic Béziers accurate enough to be visually
+// indistinguishable from the true arc, calling yield once per curve
+// with its two control points and endpoint.  Backends with no native
+// elliptical-arc support (the PDF backend, and any future Canvas
+// lacking one) use this instead of emitting comp directly; vgsvg can
+// keep using native SVG "A" commands unchanged.
+//
+// The arc is split into segments of at most π/2: starting at angle
+// a1 with remaining sweep left, each step covers a2 = a1 +
+// sign·min(π/2, left), and the cubic's control points are offset
+// tangentially from the arc by r·(4/3)·tan((a2-a1)/4).
+func FlattenArc(comp PathComp, yield func(c0, c1, end Point)) {
+	if comp.Type != ArcComp {
+		panic("vg: FlattenArc called on a non-arc PathComp")
+	}
+
+	center := Point{X: comp.X, Y: comp.Y}
+	r := comp.Radius
+
+	sign := 1.0
+	if comp.Angle < 0 {
+		sign = -1
+	}
+
+	a1 := comp.Start
+	left := math.Abs(comp.Angle)
+	for left >= math.Sqrt(epsilon) {
+		step := math.Min(math.Pi/2, left)
+		a2 := a1 + sign*step
+
+		p1 := arcPoint(center, r, a1)
+		p2 := arcPoint(center, r, a2)
+
+		k := r * Length(4.0/3.0*math.Tan((a2-a1)/4))
+		c0 := Point{X: p1.X - Length(math.Sin(a1))*k, Y: p1.Y + Length(math.Cos(a1))*k}
+		c1 := Point{X: p2.X + Length(math.Sin(a2))*k, Y: p2.Y - Length(math.Cos(a2))*k}
+
+		yield(c0, c1, p2)
+
+		a1 = a2
+		left -= step
+	}
+}
+
+// arcPoint returns the point at angle a on the circle of radius r
+// centered at center.
+func arcPoint(center Point, r Length, a float64) Point {
+	return Point{
+		X: center.X + r*Length(math.Cos(a)),
+		Y: center.Y + r*Length(math.Sin(a)),
+	}
+}
+
+// Flatten returns a copy of p with every ArcComp replaced by the
+// sequence of CurveComps FlattenArc produces for it, so that p can be
+// drawn on a backend with no native elliptical-arc support.  When the
+// current point is not already at the arc's start, a LineComp to that
+// start precedes the curves.  Other components are copied unchanged.
+func (p Path) Flatten() Path {
+	flat := make(Path, 0, len(p))
+	var x, y, moveX, moveY Length
+	for _, comp := range p {
+		if comp.Type != ArcComp {
+			flat = append(flat, comp)
+			switch comp.Type {
+			case MoveComp:
+				x, y = comp.X, comp.Y
+				moveX, moveY = x, y
+			case CloseComp:
+				// CloseComp carries no position of its own; it
+				// draws back to the start of the current subpath.
+				x, y = moveX, moveY
+			default:
+				x, y = comp.X, comp.Y
+			}
+			continue
+		}
+
+		r := comp.Radius
+		x0 := comp.X + r*Length(math.Cos(comp.Start))
+		y0 := comp.Y + r*Length(math.Sin(comp.Start))
+		if x0 != x || y0 != y {
+			flat = append(flat, PathComp{Type: LineComp, X: x0, Y: y0})
+		}
+
+		FlattenArc(comp, func(c0, c1, end Point) {
+			flat = append(flat, PathComp{
+				Type:    CurveComp,
+				Control: []Point{c0, c1},
+				X:       end.X,
+				Y:       end.Y,
+			})
+			x, y = end.X, end.Y
+		})
+	}
+	return flat
+}
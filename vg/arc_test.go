@@ -0,0 +1,80 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package vg
+
+import (
+	"math"
+	"testing"
+)
+
+// dist returns the Euclidean distance between a and b.
+func dist(a, b Point) float64 {
+	dx := float64(a.X - b.X)
+	dy := float64(a.Y - b.Y)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// TestFlattenArcEndpointsOnCircle checks that every curve FlattenArc
+// yields has both its start (implicitly the previous end) and end
+// point lying on the arc's circle, for a partial sweep and for a full
+// circle.
+func TestFlattenArcEndpointsOnCircle(t *testing.T) {
+	center := Point{X: 10, Y: -5}
+	r := Length(7)
+
+	for _, angle := range []float64{math.Pi / 6, math.Pi, 1.9 * math.Pi, 2 * math.Pi, -2 * math.Pi} {
+		comp := PathComp{Type: ArcComp, X: center.X, Y: center.Y, Radius: r, Start: 0.3, Angle: angle}
+
+		n := 0
+		FlattenArc(comp, func(c0, c1, end Point) {
+			n++
+			got := dist(center, end)
+			if math.Abs(got-float64(r)) > 1e-6 {
+				t.Errorf("angle=%v: endpoint %v is %v from center, want %v", angle, end, got, float64(r))
+			}
+		})
+		if n == 0 {
+			t.Errorf("angle=%v: FlattenArc yielded no curves", angle)
+		}
+	}
+}
+
+// TestFlattenArcSweepBound checks that no single curve spans more
+// than pi/2 of arc, by counting curves against the expected minimum.
+func TestFlattenArcSweepBound(t *testing.T) {
+	comp := PathComp{Type: ArcComp, Radius: 1, Angle: 1.9 * math.Pi}
+
+	n := 0
+	FlattenArc(comp, func(c0, c1, end Point) { n++ })
+
+	want := int(math.Ceil(math.Abs(comp.Angle) / (math.Pi / 2)))
+	if n < want {
+		t.Errorf("got %d curves for a %v sweep, want at least %d", n, comp.Angle, want)
+	}
+}
+
+// TestPathFlattenClosePreservesSubpathOrigin checks that an ArcComp
+// following a CloseComp is measured from the subpath's MoveComp
+// origin, not from (0, 0).
+func TestPathFlattenClosePreservesSubpathOrigin(t *testing.T) {
+	p := Path{
+		{Type: MoveComp, X: 100, Y: 100},
+		{Type: LineComp, X: 110, Y: 100},
+		{Type: CloseComp},
+		{Type: ArcComp, X: 90, Y: 100, Radius: 10, Start: 0, Angle: math.Pi / 2},
+	}
+
+	flat := p.Flatten()
+
+	// The arc starts at angle 0 around (90,100) with radius 10,
+	// i.e. at (100,100) -- exactly where the subpath was closed back
+	// to, so Flatten should NOT have injected a spurious LineComp.
+	for _, comp := range flat[3:] {
+		if comp.Type == LineComp {
+			t.Errorf("Flatten injected a LineComp to %v,%v; subpath was already at the arc's start",
+				comp.X, comp.Y)
+		}
+	}
+}
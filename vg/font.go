@@ -0,0 +1,121 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package vg
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// postScriptFonts are the 12 standard PostScript font names that
+// every vg backend has always been expected to render natively,
+// without any embedded glyph data.
+var postScriptFonts = []string{
+	"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique",
+	"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique",
+	"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic",
+}
+
+// A FontFace is the data a backend needs to render and, if
+// necessary, embed one Typeface registered in a FontCache.
+type FontFace struct {
+	// Name is the Typeface name the face is registered under, as
+	// returned by Font.Name.
+	Name string
+
+	// PostScript is true for the 12 standard fonts that backends
+	// are assumed to support by name alone; such faces have no
+	// Data or Sfnt to embed.
+	PostScript bool
+
+	// Data holds the raw TTF/OTF bytes the face was registered
+	// with.  It is nil for PostScript faces.
+	Data []byte
+
+	// Sfnt is the parsed representation of Data, used by backends
+	// that rasterize or extract outlines rather than simply
+	// naming the family in a font-family style.  It is nil for
+	// PostScript faces.
+	Sfnt *sfnt.Font
+}
+
+// Base64 returns the face's raw font data, base64-encoded, for
+// embedding in a data URI such as an SVG @font-face src or a PDF
+// embedded font stream.  It panics if called on a PostScript face,
+// which has no data to embed.
+func (f *FontFace) Base64() string {
+	if f.PostScript {
+		panic("vg: no embeddable data for PostScript font " + f.Name)
+	}
+	return base64.StdEncoding.EncodeToString(f.Data)
+}
+
+// A FontCache maps Typeface names to the font data used to render
+// and, when necessary, embed them.  Use NewFontCache to create one;
+// the zero value is not usable.
+type FontCache struct {
+	mu    sync.RWMutex
+	faces map[string]*FontFace
+}
+
+// NewFontCache returns a FontCache seeded with the 12 standard
+// PostScript fonts that vg backends have always supported.
+func NewFontCache() *FontCache {
+	c := &FontCache{faces: make(map[string]*FontFace)}
+	for _, name := range postScriptFonts {
+		c.faces[name] = &FontFace{Name: name, PostScript: true}
+	}
+	return c
+}
+
+// RegisterFont parses a TrueType or OpenType font face from data and
+// adds it to the cache under name, so that a plot.TextStyle or
+// plot.Font can refer to it as a Typeface without any backend needing
+// to be edited.
+func (c *FontCache) RegisterFont(name string, data []byte) error {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return fmt.Errorf("vg: parsing font %q: %v", name, err)
+	}
+
+	c.mu.Lock()
+	c.faces[name] = &FontFace{Name: name, Data: data, Sfnt: f}
+	c.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the FontFace registered under name, and whether one
+// was found.
+func (c *FontCache) Lookup(name string) (*FontFace, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	f, ok := c.faces[name]
+	return f, ok
+}
+
+// DefaultFontCache is the FontCache consulted by every vg backend
+// unless told otherwise.  RegisterFont adds a face to it.
+var DefaultFontCache = NewFontCache()
+
+// RegisterFont parses a TrueType or OpenType font face from data and
+// adds it to DefaultFontCache under name, e.g. to render CJK, math,
+// or corporate fonts that the 12 built-in PostScript names can't
+// express:
+//
+//	f, err := ioutil.ReadFile("NotoSansCJK-Regular.ttf")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := vg.RegisterFont("NotoSansCJK", f); err != nil {
+//		log.Fatal(err)
+//	}
+//	// plot.Font{Name: "NotoSansCJK", Size: vg.Points(12)} now renders.
+func RegisterFont(name string, data []byte) error {
+	return DefaultFontCache.RegisterFont(name, data)
+}
@@ -0,0 +1,88 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package vg
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestFontCacheSeededWithPostScriptFonts checks that a new FontCache
+// already knows the 12 standard PostScript names.
+func TestFontCacheSeededWithPostScriptFonts(t *testing.T) {
+	c := NewFontCache()
+	for _, name := range postScriptFonts {
+		f, ok := c.Lookup(name)
+		if !ok {
+			t.Errorf("Lookup(%q): not found in a fresh FontCache", name)
+			continue
+		}
+		if !f.PostScript {
+			t.Errorf("Lookup(%q): PostScript = false, want true", name)
+		}
+	}
+}
+
+// TestFontCacheLookupMiss checks that an unregistered name is
+// reported as not found rather than returning a zero-value face.
+func TestFontCacheLookupMiss(t *testing.T) {
+	c := NewFontCache()
+	if _, ok := c.Lookup("NotRegistered"); ok {
+		t.Errorf("Lookup(%q): ok = true, want false", "NotRegistered")
+	}
+}
+
+// TestRegisterFontMalformedData checks that RegisterFont reports an
+// error, rather than panicking or silently registering a broken face,
+// when given data that isn't a valid TTF/OTF font.
+func TestRegisterFontMalformedData(t *testing.T) {
+	c := NewFontCache()
+	err := c.RegisterFont("Bogus", []byte("this is not a font"))
+	if err == nil {
+		t.Fatal("RegisterFont with malformed data returned a nil error")
+	}
+
+	if _, ok := c.Lookup("Bogus"); ok {
+		t.Error("RegisterFont with malformed data still registered a face")
+	}
+}
+
+// TestFontFaceBase64PanicsOnPostScript checks that Base64 refuses to
+// run on a PostScript face, which has no embeddable data.
+func TestFontFaceBase64PanicsOnPostScript(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Base64 on a PostScript face did not panic")
+		}
+	}()
+
+	c := NewFontCache()
+	f, ok := c.Lookup("Helvetica")
+	if !ok {
+		t.Fatal("Lookup(\"Helvetica\"): not found")
+	}
+	f.Base64()
+}
+
+// TestFontCacheConcurrentLookupAndRegister exercises Lookup and
+// RegisterFont from many goroutines at once, under the race detector,
+// to catch any access to faces that isn't guarded by mu.
+func TestFontCacheConcurrentLookupAndRegister(t *testing.T) {
+	c := NewFontCache()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.Lookup("Helvetica")
+		}()
+		go func() {
+			defer wg.Done()
+			c.RegisterFont("Bogus", []byte("not a font"))
+		}()
+	}
+	wg.Wait()
+}
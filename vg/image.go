@@ -0,0 +1,68 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package vg
+
+import "image"
+
+// A Point is a location on a Canvas.
+type Point struct {
+	X, Y Length
+}
+
+// A Rectangle is an axis-aligned rectangular region of a Canvas,
+// such as the area an embedded image is drawn to cover.
+type Rectangle struct {
+	Min, Max Point
+}
+
+// Size returns the width and height of r.
+func (r Rectangle) Size() Point {
+	return Point{X: r.Max.X - r.Min.X, Y: r.Max.Y - r.Min.Y}
+}
+
+// An ImageCanvas is a Canvas that can draw a raster image directly,
+// rather than making callers approximate it with one Fill per pixel.
+// Canvas implementations add this by implementing DrawImage;
+// DrawImageOrApproximate detects it with a type assertion.
+type ImageCanvas interface {
+	Canvas
+
+	// DrawImage draws img so that it exactly covers rect.
+	DrawImage(rect Rectangle, img image.Image)
+}
+
+// DrawImageOrApproximate draws img covering rect on c, using c's own
+// DrawImage if c implements ImageCanvas, or otherwise approximating
+// it by filling one rectangle per source pixel.  The fallback is
+// legible but slow, and exists only for Canvas implementations that
+// predate ImageCanvas.
+func DrawImageOrApproximate(c Canvas, rect Rectangle, img image.Image) {
+	if ic, ok := c.(ImageCanvas); ok {
+		ic.DrawImage(rect, img)
+		return
+	}
+
+	b := img.Bounds()
+	size := rect.Size()
+	pxW := size.X / Length(b.Dx())
+	pxH := size.Y / Length(b.Dy())
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			px := rect.Min.X + Length(x-b.Min.X)*pxW
+			py := rect.Max.Y - Length(y-b.Min.Y+1)*pxH
+
+			var path Path
+			path.Move(px, py)
+			path.Line(px+pxW, py)
+			path.Line(px+pxW, py+pxH)
+			path.Line(px, py+pxH)
+			path.Close()
+
+			c.SetColor(img.At(x, y))
+			c.Fill(path)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2012 The Plotinum Authors. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package vg
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// recordingCanvas is a minimal Canvas that also implements
+// ImageCanvas, so a test can tell whether DrawImageOrApproximate took
+// the fast native path or the one-Fill-per-pixel fallback.
+type recordingCanvas struct {
+	drewImage bool
+	fills     int
+}
+
+func (c *recordingCanvas) SetLineWidth(w Length)                    {}
+func (c *recordingCanvas) SetLineDash(d []Length, o Length)         {}
+func (c *recordingCanvas) SetColor(clr color.Color)                 {}
+func (c *recordingCanvas) Rotate(radians float64)                   {}
+func (c *recordingCanvas) Translate(x, y Length)                    {}
+func (c *recordingCanvas) Scale(x, y float64)                       {}
+func (c *recordingCanvas) Push()                                    {}
+func (c *recordingCanvas) Pop()                                     {}
+func (c *recordingCanvas) Stroke(p Path)                            {}
+func (c *recordingCanvas) Fill(p Path)                              { c.fills++ }
+func (c *recordingCanvas) FillString(f Font, x, y Length, s string) {}
+func (c *recordingCanvas) DPI() float64                             { return 90 }
+
+func (c *recordingCanvas) DrawImage(rect Rectangle, img image.Image) {
+	c.drewImage = true
+}
+
+// wrappingCanvas embeds a Canvas exactly the way plot.DrawArea embeds
+// vg.Canvas: its promoted method set is whatever Canvas declares,
+// with no DrawImage of its own even when the embedded value has one.
+type wrappingCanvas struct {
+	Canvas
+}
+
+func testImage() image.Image {
+	return image.NewRGBA(image.Rect(0, 0, 2, 2))
+}
+
+// TestDrawImageOrApproximateThroughWrapper reproduces the bug where
+// passing an embedding wrapper (the same shape as plot.DrawArea) to
+// DrawImageOrApproximate always misses the ImageCanvas fast path,
+// even though the canvas it wraps implements it; passing the
+// embedded Canvas field directly must hit the fast path.
+func TestDrawImageOrApproximateThroughWrapper(t *testing.T) {
+	inner := &recordingCanvas{}
+	wrapper := wrappingCanvas{Canvas: inner}
+	rect := Rectangle{Min: Point{X: 0, Y: 0}, Max: Point{X: 10, Y: 10}}
+
+	DrawImageOrApproximate(wrapper.Canvas, rect, testImage())
+	if !inner.drewImage {
+		t.Errorf("DrawImageOrApproximate(wrapper.Canvas, ...) did not take the ImageCanvas fast path")
+	}
+	if inner.fills != 0 {
+		t.Errorf("DrawImageOrApproximate(wrapper.Canvas, ...) also ran %d Fill calls", inner.fills)
+	}
+
+	inner2 := &recordingCanvas{}
+	wrapper2 := wrappingCanvas{Canvas: inner2}
+
+	DrawImageOrApproximate(wrapper2, rect, testImage())
+	if inner2.drewImage {
+		t.Errorf("DrawImageOrApproximate(wrapper, ...) unexpectedly took the fast path; " +
+			"wrapper's promoted method set should not include DrawImage")
+	}
+	if inner2.fills == 0 {
+		t.Errorf("DrawImageOrApproximate(wrapper, ...) took neither path")
+	}
+}
@@ -10,9 +10,15 @@ import (
 	"bufio"
 	"bytes"
 	"code.google.com/p/plotinum/vg"
+	"encoding/base64"
 	"fmt"
 	svgo "github.com/ajstarks/svgo"
+	sfntfont "golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"image"
 	"image/color"
+	"image/png"
 	"io"
 	"math"
 )
@@ -32,6 +38,29 @@ type Canvas struct {
 	buf *bytes.Buffer
 	ht  float64
 	stk []context
+
+	// embedded tracks which non-PostScript fonts have already had
+	// their @font-face block written, so each is embedded once no
+	// matter how many times it is used.
+	embedded map[string]bool
+
+	// TextAsPaths, when true, makes FillString emit each glyph as an
+	// SVG <path> built from the font's own outline data instead of a
+	// <text> element.  The resulting SVG renders identically
+	// regardless of which fonts are installed on the viewer, at the
+	// cost of a larger file.  It has no effect for fonts that have
+	// no parsed outline data available, i.e. the built-in PostScript
+	// fonts, which fall back to <text> as usual.
+	//
+	// This does not touch font.Extents or font.Width: those metrics
+	// aren't implemented in this tree, so there is no bounds cache
+	// to keep in sync with the rasterized path extents here.
+	TextAsPaths bool
+
+	// glyphs caches the SVG path data and advance width already
+	// extracted for a (face, rune, size) triple, so repeated runes
+	// are only rasterized once.
+	glyphs map[glyphKey]glyphPath
 }
 
 type context struct {
@@ -130,6 +159,23 @@ func (c *Canvas) Fill(path vg.Path) {
 		style(elm("fill", "#000000", colorString(c.cur().color))))
 }
 
+// DrawImage implements the vg.ImageCanvas interface, embedding img as
+// a base64-encoded PNG data URI positioned to exactly cover rect,
+// with the same scale(1,-1) coordinate flip FillString uses so the
+// image isn't drawn upside down.
+func (c *Canvas) DrawImage(rect vg.Rectangle, img image.Image) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(fmt.Sprintf("vgsvg: encoding image: %v", err))
+	}
+
+	size := rect.Size()
+	fmt.Fprintf(c.buf, `<image xlink:href="data:image/png;base64,%s" x="%.*g" y="%.*g" width="%.*g" height="%.*g" transform="scale(1, -1)"/>`+"\n",
+		base64.StdEncoding.EncodeToString(buf.Bytes()),
+		pr, rect.Min.X.Dots(c), pr, -rect.Max.Y.Dots(c),
+		pr, size.X.Dots(c), pr, size.Y.Dots(c))
+}
+
 func (c *Canvas) pathData(path vg.Path) string {
 	buf := new(bytes.Buffer)
 	var x, y float64
@@ -231,10 +277,24 @@ func large(a float64) int {
 }
 
 func (c *Canvas) FillString(font vg.Font, x, y vg.Length, str string) {
-	fontStr, ok := fontMap[font.Name()]
+	face, ok := vg.DefaultFontCache.Lookup(font.Name())
 	if !ok {
 		panic(fmt.Sprintf("Unknown font: %s", font.Name()))
 	}
+
+	if c.TextAsPaths && face.Sfnt != nil {
+		c.fillStringAsPaths(face, font, x, y, str)
+		return
+	}
+
+	var fontStr string
+	if face.PostScript {
+		fontStr = fontMap[face.Name]
+	} else {
+		fontStr = fmt.Sprintf("font-family:%q", face.Name)
+		c.embedFont(face)
+	}
+
 	sty := style(fontStr,
 		elm("font-size", "medium", "%.*gpt", pr, font.Size.Points()),
 		elm("fill", "#000000", colorString(c.cur().color)))
@@ -245,6 +305,134 @@ func (c *Canvas) FillString(font vg.Font, x, y vg.Length, str string) {
 		pr, x.Dots(c), pr, -y.Dots(c), sty, str)
 }
 
+// glyphKey identifies a cached glyph outline: the face it came from,
+// which rune, and at what size.  Size is part of the key because the
+// cached path data is already scaled to device units.
+type glyphKey struct {
+	face *vg.FontFace
+	r    rune
+	size vg.Length
+}
+
+// glyphPath is the cached SVG path data and horizontal advance for a
+// single glyph, both already scaled for glyphKey.size.  data is
+// relative to the glyph's own origin; advance is in the same Dots
+// units as the rest of the Canvas.
+type glyphPath struct {
+	data    string
+	advance float64
+}
+
+// fillStringAsPaths implements FillString when TextAsPaths is set,
+// emitting str as one filled <path> per glyph, built from face's
+// outline data, instead of a <text> element.
+func (c *Canvas) fillStringAsPaths(face *vg.FontFace, font vg.Font, x, y vg.Length, str string) {
+	if c.glyphs == nil {
+		c.glyphs = make(map[glyphKey]glyphPath)
+	}
+
+	scale := font.Size.Dots(c) / float64(face.Sfnt.UnitsPerEm())
+	ppem := fixed.Int26_6(font.Size.Dots(c) * 64)
+	originX, originY := x.Dots(c), -y.Dots(c)
+
+	var buf sfnt.Buffer
+	dx := 0.0
+	var prev sfnt.GlyphIndex
+	for i, r := range str {
+		key := glyphKey{face, r, font.Size}
+		gp, ok := c.glyphs[key]
+		if !ok {
+			gp = rasterizeGlyph(face.Sfnt, &buf, r, scale)
+			c.glyphs[key] = gp
+		}
+
+		idx, _ := face.Sfnt.GlyphIndex(&buf, r)
+		if i > 0 {
+			if kern, err := face.Sfnt.Kern(&buf, prev, idx, ppem, sfntfont.HintingNone); err == nil {
+				dx += float64(kern) / 64
+			}
+		}
+
+		if gp.data != "" {
+			fmt.Fprintf(c.buf, `<g transform="scale(1, -1) translate(%.*g,%.*g)"><path d="%s" style="fill:%s"/></g>`+"\n",
+				pr, originX+dx, pr, originY, gp.data, colorString(c.cur().color))
+		}
+		dx += gp.advance
+		prev = idx
+	}
+}
+
+// rasterizeGlyph extracts r's outline from f as SVG path "d" data,
+// scaled by scale, and returns it along with the glyph's advance
+// width in the same scale.  The outline's origin is the glyph's own
+// origin; callers position it with a translate.
+func rasterizeGlyph(f *sfnt.Font, buf *sfnt.Buffer, r rune, scale float64) glyphPath {
+	idx, err := f.GlyphIndex(buf, r)
+	if err != nil || idx == 0 {
+		return glyphPath{}
+	}
+
+	fpem := fixed.Int26_6(scale * float64(f.UnitsPerEm()) * 64)
+
+	var gp glyphPath
+	if adv, err := f.GlyphAdvance(buf, idx, fpem, sfntfont.HintingNone); err == nil {
+		gp.advance = float64(adv) / 64
+	}
+
+	segs, err := f.LoadGlyph(buf, idx, fpem, nil)
+	if err != nil || len(segs) == 0 {
+		// Glyphs like the space character have a valid index but no
+		// outline; leave gp.data empty so callers skip emitting a
+		// <path>, rather than a bare "Z" with no preceding moveto.
+		return gp
+	}
+
+	var path bytes.Buffer
+	for _, seg := range segs {
+		p0 := seg.Args[0]
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			fmt.Fprintf(&path, "M%.*g,%.*g", pr, f26(p0.X), pr, f26(p0.Y))
+		case sfnt.SegmentOpLineTo:
+			fmt.Fprintf(&path, "L%.*g,%.*g", pr, f26(p0.X), pr, f26(p0.Y))
+		case sfnt.SegmentOpQuadTo:
+			p1 := seg.Args[1]
+			fmt.Fprintf(&path, "Q%.*g,%.*g %.*g,%.*g",
+				pr, f26(p0.X), pr, f26(p0.Y), pr, f26(p1.X), pr, f26(p1.Y))
+		case sfnt.SegmentOpCubeTo:
+			p1, p2 := seg.Args[1], seg.Args[2]
+			fmt.Fprintf(&path, "C%.*g,%.*g %.*g,%.*g %.*g,%.*g",
+				pr, f26(p0.X), pr, f26(p0.Y), pr, f26(p1.X), pr, f26(p1.Y), pr, f26(p2.X), pr, f26(p2.Y))
+		}
+	}
+	path.WriteString("Z")
+	gp.data = path.String()
+	return gp
+}
+
+// f26 converts a 26.6 fixed-point font unit, already scaled to device
+// units by the ppem passed to LoadGlyph, to a plain float64.
+func f26(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
+
+// embedFont writes an inline @font-face block embedding face's raw
+// TTF/OTF data as a base64 data URI, the first time face is used on
+// c.  SVG allows a <style> element anywhere in the document, so it is
+// written in place rather than collected into a separate header.
+func (c *Canvas) embedFont(face *vg.FontFace) {
+	if c.embedded == nil {
+		c.embedded = make(map[string]bool)
+	}
+	if c.embedded[face.Name] {
+		return
+	}
+	c.embedded[face.Name] = true
+
+	fmt.Fprintf(c.buf, "<style>@font-face{font-family:%q;src:url(data:font/ttf;base64,%s);}</style>\n",
+		face.Name, face.Base64())
+}
+
 var (
 	// fontMap maps Postscript-style font names to their
 	// corresponding SVG style string.